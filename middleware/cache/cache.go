@@ -3,6 +3,8 @@
 package cache
 
 import (
+	"fmt"
+	"net/http"
 	"strconv"
 	"sync"
 	"sync/atomic"
@@ -10,6 +12,7 @@ import (
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/utils"
+	"github.com/valyala/fasthttp"
 )
 
 // timestampUpdatePeriod is the period which is used to check the cache expiration.
@@ -21,12 +24,22 @@ const timestampUpdatePeriod = 300 * time.Millisecond
 // unreachable: when cache is bypass, or invalid
 // hit: cache is served
 // miss: do not have cache record
+// revalidated: a stale entry was confirmed still valid by the origin and served again
+// stale: a stale entry was served as-is, per stale-while-revalidate/stale-if-error
 const (
 	cacheUnreachable = "unreachable"
 	cacheHit         = "hit"
 	cacheMiss        = "miss"
+	cacheRevalidated = "revalidated"
+	cacheStale       = "stale"
 )
 
+// refreshBypassHeader is set on the cloned request a stale-while-revalidate
+// background refresh replays through the app, so this same middleware
+// instance can recognise it and step aside instead of serving the
+// still-stale entry back to itself - see triggerBackgroundRefresh.
+const refreshBypassHeader = "X-Fiber-Cache-Refresh"
+
 var ignoreHeaders = map[string]interface{}{
 	"Connection":          nil,
 	"Keep-Alive":          nil,
@@ -42,6 +55,14 @@ var ignoreHeaders = map[string]interface{}{
 
 // New creates a new middleware handler
 func New(config ...Config) fiber.Handler {
+	handler, _ := NewWithMetrics(config...)
+	return handler
+}
+
+// NewWithMetrics behaves like New, but also returns a live *Metrics for this
+// middleware instance, so operators can size the cache by serving its
+// hit/miss/eviction counts from their own endpoint, e.g. "/cache/metrics".
+func NewWithMetrics(config ...Config) (fiber.Handler, *Metrics) {
 	// Set default config
 	cfg := configDefault(config...)
 
@@ -49,7 +70,7 @@ func New(config ...Config) fiber.Handler {
 	if int(cfg.Expiration.Seconds()) < 0 {
 		return func(c *fiber.Ctx) error {
 			return c.Next()
-		}
+		}, &Metrics{}
 	}
 
 	var (
@@ -58,7 +79,17 @@ func New(config ...Config) fiber.Handler {
 		timestamp = uint64(time.Now().Unix())
 	)
 	// Create manager to simplify storage operations ( see manager.go )
-	manager := newManager(cfg.Storage)
+	manager := newManager(cfg)
+
+	// refreshing single-flights background stale-while-revalidate refreshes,
+	// so a burst of requests for the same stale entry triggers at most one.
+	refreshing := &sync.Map{}
+
+	// refreshToken marks requests this middleware instance replays itself
+	// for a background refresh, so it can recognise and skip them. It's
+	// derived from manager's address, which is unique per New/NewWithMetrics
+	// call and therefore per middleware instance.
+	refreshToken := fmt.Sprintf("%p", manager)
 
 	// Update timestamp in the configured interval
 	go func() {
@@ -68,17 +99,45 @@ func New(config ...Config) fiber.Handler {
 		}
 	}()
 
-	// Return new handler
-	return func(c *fiber.Ctx) error {
+	// Build the handler
+	handler := func(c *fiber.Ctx) error {
+		// This request is a background stale-while-revalidate replay of
+		// this same middleware instance (see triggerBackgroundRefresh):
+		// step aside and let it reach the real handler instead of serving
+		// the still-stale entry back to itself.
+		if c.Get(refreshBypassHeader) == refreshToken {
+			return c.Next()
+		}
+
 		// Only cache GET and HEAD methods
 		if c.Method() != fiber.MethodGet && c.Method() != fiber.MethodHead {
 			c.Set(cfg.CacheHeader, cacheUnreachable)
 			return c.Next()
 		}
 
+		// Parse the request's own Cache-Control directives, if any
+		var reqDirectives cacheControlDirectives
+		if *cfg.HonorRequestDirectives {
+			reqDirectives = parseCacheControl(c.Get(fiber.HeaderCacheControl))
+			if reqDirectives.noStore {
+				c.Set(cfg.CacheHeader, cacheUnreachable)
+				return c.Next()
+			}
+		}
+
 		// Get key from request
 		// TODO(allocation optimization): try to minimize the allocation from 2 to 1
-		key := cfg.KeyGenerator(c) + "_" + c.Method()
+		baseKey := cfg.KeyGenerator(c) + "_" + c.Method()
+		key := baseKey
+
+		// If this resource was previously seen to vary on a set of request
+		// headers, remap the lookup to the variant matching this request's
+		// current header values.
+		if *cfg.HonorVaryHeader {
+			if vd := manager.getVary(baseKey + "_vary"); vd != nil {
+				key = baseKey + varyKeySuffix(c, vd.names)
+			}
+		}
 
 		// Get entry from pool
 		e := manager.get(key)
@@ -89,37 +148,95 @@ func New(config ...Config) fiber.Handler {
 		// Get timestamp
 		ts := atomic.LoadUint64(&timestamp)
 
-		if e.exp != 0 && ts >= e.exp {
-			// Check if entry is expired
-			manager.delete(key)
-			// External storage saves body data with different key
-			if cfg.Storage != nil {
-				manager.delete(key + "_body")
-			}
-		} else if e.exp != 0 {
-			// Separate body value to avoid msgp serialization
-			// We can store raw bytes with Storage 👍
-			if cfg.Storage != nil {
+		timeFresh := e.exp != 0 && ts < e.exp
+		servable := timeFresh && !e.mustRevalidate
+		if servable && *cfg.HonorRequestDirectives {
+			servable = isRequestFresh(reqDirectives, e, ts)
+		}
+
+		// A client-supplied max-stale can accept an entry that has already
+		// passed its freshness lifetime, sparing it from eviction below.
+		staleButAcceptable := !timeFresh && e.exp != 0 && !e.mustRevalidate &&
+			*cfg.HonorRequestDirectives && isRequestFresh(reqDirectives, e, ts)
+
+		hasValidator := len(e.etag) > 0 || len(e.lastModified) > 0
+		revalidating := false
+		pendingStaleOnError := false
+
+		// Past its freshness lifetime but still within its
+		// stale-while-revalidate grace period: serve it as-is right now and
+		// refresh it in the background instead of making this request wait.
+		// A client's own no-cache rules this out.
+		withinStaleWindow := e.exp != 0 && !timeFresh && !staleButAcceptable &&
+			e.staleExp != 0 && ts < e.staleExp && !reqDirectives.noCache
+
+		if withinStaleWindow {
+			if cfg.Storage != nil && e.body == nil {
 				e.body = manager.getRaw(key + "_body")
 			}
-			// Set response headers from cache
-			c.Response().SetBodyRaw(e.body)
-			c.Response().SetStatusCode(e.status)
-			c.Response().Header.SetContentTypeBytes(e.ctype)
-			if len(e.cencoding) > 0 {
-				c.Response().Header.SetBytesV(fiber.HeaderContentEncoding, e.cencoding)
-			}
-			if e.headers != nil {
-				for k, v := range e.headers {
-					c.Response().Header.SetBytesV(k, v)
+			serveCached(c, cfg, e, ts)
+			manager.recordHit()
+			c.Set(cfg.CacheHeader, cacheStale)
+			mux.Unlock()
+
+			triggerBackgroundRefresh(c, cfg, manager, mux, &timestamp, refreshing, key, refreshToken)
+
+			return nil
+		}
+
+		if e.exp != 0 && !timeFresh && !staleButAcceptable {
+			switch {
+			case hasValidator:
+				// Entry is stale but carries a validator: ask the origin to
+				// revalidate it instead of discarding it outright. Fetch the
+				// body now so it's ready to be re-served on a 304 below.
+				if cfg.Storage != nil && e.body == nil {
+					e.body = manager.getRaw(key + "_body")
+				}
+				if len(e.etag) > 0 {
+					c.Request().Header.SetBytesV(fiber.HeaderIfNoneMatch, e.etag)
+				}
+				if len(e.lastModified) > 0 {
+					c.Request().Header.SetBytesV(fiber.HeaderIfModifiedSince, e.lastModified)
+				}
+				revalidating = true
+				// Within its stale-if-error grace period, the loaded copy is
+				// also the fallback if the origin errors below.
+				pendingStaleOnError = e.errorExp != 0 && ts < e.errorExp
+			case e.errorExp != 0 && ts < e.errorExp:
+				// Entry can't be revalidated, but it's within its
+				// stale-if-error grace period: keep its body loaded in case
+				// the origin errors below, even though it can't be served now.
+				if cfg.Storage != nil && e.body == nil {
+					e.body = manager.getRaw(key + "_body")
+				}
+				pendingStaleOnError = true
+			default:
+				// Entry has passed its freshness lifetime and can't be
+				// revalidated: drop it and fall through to refresh it via c.Next().
+				manager.delete(key)
+				// External storage saves body data with different key
+				if cfg.Storage != nil {
+					manager.delete(key + "_body")
 				}
 			}
-			// Set Cache-Control header if enabled
-			if cfg.CacheControl {
-				maxAge := strconv.FormatUint(e.exp-ts, 10)
-				c.Set(fiber.HeaderCacheControl, "public, max-age="+maxAge)
+		} else if servable || staleButAcceptable {
+			if notModified(c, e) {
+				c.Response().SetStatusCode(fiber.StatusNotModified)
+				setValidatorHeaders(c, e)
+				manager.recordHit()
+				c.Set(cfg.CacheHeader, cacheHit)
+				mux.Unlock()
+				return nil
 			}
 
+			// Separate body value to avoid msgp serialization
+			// We can store raw bytes with Storage 👍
+			if cfg.Storage != nil {
+				e.body = manager.getRaw(key + "_body")
+			}
+			serveCached(c, cfg, e, ts)
+			manager.recordHit()
 			c.Set(cfg.CacheHeader, cacheHit)
 
 			mux.Unlock()
@@ -133,6 +250,16 @@ func New(config ...Config) fiber.Handler {
 
 		// Continue stack, return err to Fiber if exist
 		if err := c.Next(); err != nil {
+			if pendingStaleOnError {
+				// The origin errored, but we have a stale copy still within
+				// its stale-if-error grace period: serve that instead.
+				mux.Lock()
+				serveCached(c, cfg, e, ts)
+				manager.recordHit()
+				c.Set(cfg.CacheHeader, cacheStale)
+				mux.Unlock()
+				return nil
+			}
 			return err
 		}
 
@@ -140,56 +267,389 @@ func New(config ...Config) fiber.Handler {
 		mux.Lock()
 		defer mux.Unlock()
 
+		if pendingStaleOnError && c.Response().StatusCode() >= fiber.StatusInternalServerError {
+			// The origin came back but with a server error: fall back to the
+			// stale copy rather than caching or forwarding the failure.
+			serveCached(c, cfg, e, ts)
+			manager.recordHit()
+			c.Set(cfg.CacheHeader, cacheStale)
+			return nil
+		}
+
+		if revalidating && c.Response().StatusCode() == fiber.StatusNotModified {
+			// The origin confirmed our stale copy is still good: refresh its
+			// expiration and re-serve the cached body instead of the
+			// origin's bodiless 304.
+			var respDirectives cacheControlDirectives
+			if *cfg.HonorResponseDirectives {
+				respDirectives = parseCacheControl(string(c.Response().Header.Peek(fiber.HeaderCacheControl)))
+			}
+			// A 304 may carry refreshed validators; adopt them if so.
+			if etag := c.Response().Header.Peek(fiber.HeaderETag); len(etag) > 0 {
+				e.etag = utils.CopyBytes(etag)
+			}
+			if lm := c.Response().Header.Peek(fiber.HeaderLastModified); len(lm) > 0 {
+				e.lastModified = utils.CopyBytes(lm)
+			}
+
+			e.stored = ts
+			e.exp = ts + computeExpiration(cfg, c, respDirectives)
+			e.staleExp, e.errorExp = computeStaleWindows(cfg, c, respDirectives, e.exp)
+
+			serveCached(c, cfg, e, ts)
+
+			bodyLen := len(e.body)
+			ttl := entryTTL(e, ts, cfg)
+			if cfg.Storage != nil {
+				manager.setRaw(key+"_body", e.body, ttl)
+				body := e.body
+				e.body = nil
+				manager.set(key, e, ttl, bodyLen)
+				e.body = body
+			} else {
+				manager.set(key, e, ttl, bodyLen)
+			}
+
+			manager.recordHit()
+			c.Set(cfg.CacheHeader, cacheRevalidated)
+			return nil
+		}
+
 		// Don't cache response if Next returns true
 		if cfg.Next != nil && cfg.Next(c) {
 			c.Set(cfg.CacheHeader, cacheUnreachable)
 			return nil
 		}
 
+		// Parse the origin response's own Cache-Control directives, if any
+		var respDirectives cacheControlDirectives
+		if *cfg.HonorResponseDirectives {
+			respDirectives = parseCacheControl(string(c.Response().Header.Peek(fiber.HeaderCacheControl)))
+			if respDirectives.noStore || respDirectives.private {
+				c.Set(cfg.CacheHeader, cacheUnreachable)
+				return nil
+			}
+		}
+
 		// Cache response
-		e.body = utils.CopyBytes(c.Response().Body())
-		e.status = c.Response().StatusCode()
-		e.ctype = utils.CopyBytes(c.Response().Header.ContentType())
-		e.cencoding = utils.CopyBytes(c.Response().Header.Peek(fiber.HeaderContentEncoding))
-
-		// Store all response headers
-		// (more: https://datatracker.ietf.org/doc/html/rfc2616#section-13.5.1)
-		if cfg.StoreResponseHeaders {
-			e.headers = make(map[string][]byte)
-			c.Response().Header.VisitAll(
-				func(key []byte, value []byte) {
-					// create real copy
-					keyS := string(key)
-					if _, ok := ignoreHeaders[keyS]; !ok {
-						e.headers[keyS] = utils.CopyBytes(value)
-					}
-				},
-			)
-		}
-
-		// default cache expiration
-		expiration := uint64(cfg.Expiration.Seconds())
-		// Calculate expiration by response header or other setting
-		if cfg.ExpirationGenerator != nil {
-			expiration = uint64(cfg.ExpirationGenerator(c, &cfg).Seconds())
-		}
-		e.exp = ts + expiration
+		fillEntry(e, c, cfg, ts, respDirectives)
+		ttl := entryTTL(e, ts, cfg)
+
+		// A Vary response header means this resource's representation
+		// depends on the listed request headers: remember that so future
+		// requests hit the right variant, and never cache a "Vary: *"
+		// response since it can depend on anything.
+		if *cfg.HonorVaryHeader {
+			if varyRaw := c.Response().Header.Peek(fiber.HeaderVary); len(varyRaw) > 0 {
+				names, varyAll := parseVary(string(varyRaw))
+				if varyAll {
+					manager.release(e)
+					c.Set(cfg.CacheHeader, cacheUnreachable)
+					return nil
+				}
+				if len(names) > 0 {
+					key = baseKey + varyKeySuffix(c, names)
+					manager.setVary(baseKey+"_vary", &varyDescriptor{names: names}, ttl)
+				}
+			}
+		}
 
 		// For external Storage we store raw body separated
+		bodyLen := len(e.body)
 		if cfg.Storage != nil {
-			manager.setRaw(key+"_body", e.body, cfg.Expiration)
+			manager.setRaw(key+"_body", e.body, ttl)
 			// avoid body msgp encoding
 			e.body = nil
-			manager.set(key, e, cfg.Expiration)
+			manager.set(key, e, ttl, bodyLen)
 			manager.release(e)
 		} else {
 			// Store entry in memory
-			manager.set(key, e, cfg.Expiration)
+			manager.set(key, e, ttl, bodyLen)
 		}
 
+		manager.recordMiss()
 		c.Set(cfg.CacheHeader, cacheMiss)
 
 		// Finish response
 		return nil
 	}
+
+	return handler, manager.metrics
+}
+
+// serveCached writes a stored entry onto the current response: body,
+// status, content type/encoding, any additional stored headers and
+// validators, plus (if enabled) a client-facing Cache-Control header
+// reflecting the entry's remaining freshness.
+func serveCached(c *fiber.Ctx, cfg Config, e *entry, ts uint64) {
+	c.Response().SetBodyRaw(e.body)
+	c.Response().SetStatusCode(e.status)
+	c.Response().Header.SetContentTypeBytes(e.ctype)
+	if len(e.cencoding) > 0 {
+		c.Response().Header.SetBytesV(fiber.HeaderContentEncoding, e.cencoding)
+	}
+	if e.headers != nil {
+		for k, v := range e.headers {
+			c.Response().Header.SetBytesV(k, v)
+		}
+	}
+	setValidatorHeaders(c, e)
+	// Set Cache-Control header if enabled
+	if cfg.CacheControl {
+		var remaining uint64
+		if ts < e.exp {
+			remaining = e.exp - ts
+		}
+		c.Set(fiber.HeaderCacheControl, "public, max-age="+strconv.FormatUint(remaining, 10))
+	}
+}
+
+// fillEntry captures c's current response into e and computes its freshness
+// and stale windows from cfg and the response's own Cache-Control (when
+// HonorResponseDirectives is enabled). The body is left attached to e;
+// callers writing to external Storage are responsible for splitting it off.
+func fillEntry(e *entry, c *fiber.Ctx, cfg Config, ts uint64, d cacheControlDirectives) {
+	e.body = utils.CopyBytes(c.Response().Body())
+	e.status = c.Response().StatusCode()
+	e.ctype = utils.CopyBytes(c.Response().Header.ContentType())
+	e.cencoding = utils.CopyBytes(c.Response().Header.Peek(fiber.HeaderContentEncoding))
+
+	// Store all response headers
+	// (more: https://datatracker.ietf.org/doc/html/rfc2616#section-13.5.1)
+	if cfg.StoreResponseHeaders {
+		e.headers = make(map[string][]byte)
+		c.Response().Header.VisitAll(
+			func(key []byte, value []byte) {
+				// create real copy
+				keyS := string(key)
+				if _, ok := ignoreHeaders[keyS]; !ok {
+					e.headers[keyS] = utils.CopyBytes(value)
+				}
+			},
+		)
+	}
+
+	e.etag = utils.CopyBytes(c.Response().Header.Peek(fiber.HeaderETag))
+	e.lastModified = utils.CopyBytes(c.Response().Header.Peek(fiber.HeaderLastModified))
+
+	e.stored = ts
+	e.mustRevalidate = *cfg.HonorResponseDirectives && d.noCache
+	e.originMustRevalidate = *cfg.HonorResponseDirectives && d.mustRevalidate
+	e.exp = ts + computeExpiration(cfg, c, d)
+	e.staleExp, e.errorExp = computeStaleWindows(cfg, c, d, e.exp)
+}
+
+// triggerBackgroundRefresh kicks off, at most once per key at a time, an
+// asynchronous re-run of the handler chain against a cloned request so a
+// stale-while-revalidate entry gets refreshed without making the request
+// that found it stale wait on the origin. refreshing single-flights this
+// across concurrent requests that observe the same stale key.
+//
+// The replay goes through app.Handler(), fasthttp's own entry point, rather
+// than a bare app.AcquireCtx: AcquireCtx hands back a Ctx with no route
+// resolved, and calling Next() on it panics. app.Handler() performs real
+// routing, so the chain - including this same cache middleware - runs as it
+// would for a genuine request; refreshToken is stamped onto the cloned
+// request so this instance recognises the replay and steps aside instead of
+// serving its own stale entry back to itself.
+func triggerBackgroundRefresh(c *fiber.Ctx, cfg Config, manager *manager, mux *sync.RWMutex, timestamp *uint64, refreshing *sync.Map, key, refreshToken string) {
+	if _, loaded := refreshing.LoadOrStore(key, struct{}{}); loaded {
+		return
+	}
+
+	fctx := &fasthttp.RequestCtx{}
+	c.Request().CopyTo(&fctx.Request)
+	fctx.Request.Header.Set(refreshBypassHeader, refreshToken)
+
+	app := c.App()
+	appHandler := app.Handler()
+
+	go func() {
+		defer refreshing.Delete(key)
+
+		appHandler(fctx)
+
+		// Used read-only below, purely to reach the now-populated
+		// fctx.Request/Response through the usual fiber.Ctx accessors; Next()
+		// is never called on it, so the missing route is never an issue.
+		ctx := app.AcquireCtx(fctx)
+		defer app.ReleaseCtx(ctx)
+
+		if cfg.Next != nil && cfg.Next(ctx) {
+			return
+		}
+
+		var respDirectives cacheControlDirectives
+		if *cfg.HonorResponseDirectives {
+			respDirectives = parseCacheControl(string(ctx.Response().Header.Peek(fiber.HeaderCacheControl)))
+			if respDirectives.noStore || respDirectives.private {
+				return
+			}
+		}
+
+		ts := atomic.LoadUint64(timestamp)
+
+		e := manager.acquire()
+		fillEntry(e, ctx, cfg, ts, respDirectives)
+		bodyLen := len(e.body)
+		ttl := entryTTL(e, ts, cfg)
+
+		mux.Lock()
+		defer mux.Unlock()
+
+		// A server error must not replace an entry that is still within its
+		// stale-if-error grace period.
+		if ctx.Response().StatusCode() >= fiber.StatusInternalServerError {
+			if old := manager.get(key); old.errorExp != 0 && ts < old.errorExp {
+				return
+			}
+		}
+
+		if cfg.Storage != nil {
+			manager.setRaw(key+"_body", e.body, ttl)
+			e.body = nil
+			manager.set(key, e, ttl, bodyLen)
+			manager.release(e)
+		} else {
+			manager.set(key, e, ttl, bodyLen)
+		}
+	}()
+}
+
+// entryTTL returns how long the backing store should retain e: long enough
+// to cover not just its freshness lifetime (e.exp) but any stale-while-revalidate
+// or stale-if-error grace period layered on top (e.staleExp/e.errorExp), so a
+// response with a max-age longer than cfg.Expiration - or a grace period that
+// extends past it - doesn't get evicted from storage before it actually goes
+// stale.
+//
+// A validator-bearing entry gets an extra cfg.Expiration-long window past
+// e.exp on top of that: conditional revalidation is only ever attempted once
+// an entry is past its freshness lifetime, so storage must keep holding it
+// past that point even when no stale-while-revalidate/stale-if-error grace
+// period is configured - otherwise every revalidation attempt finds the
+// entry already gone and falls through to a plain cache miss instead.
+//
+// The same extra window applies while HonorRequestDirectives is enabled, so a
+// client's max-stale can still find the entry past e.exp, unless the origin
+// sent no-cache or must-revalidate/proxy-revalidate. A max-stale beyond that
+// window finds the entry gone.
+func entryTTL(e *entry, ts uint64, cfg Config) time.Duration {
+	exp := e.exp
+	if e.staleExp > exp {
+		exp = e.staleExp
+	}
+	if e.errorExp > exp {
+		exp = e.errorExp
+	}
+	maxStaleServable := *cfg.HonorRequestDirectives && !e.mustRevalidate && !e.originMustRevalidate
+	if len(e.etag) > 0 || len(e.lastModified) > 0 || maxStaleServable {
+		if validatorExp := e.exp + uint64(cfg.Expiration.Seconds()); validatorExp > exp {
+			exp = validatorExp
+		}
+	}
+	if exp <= ts {
+		return 0
+	}
+	return time.Duration(exp-ts) * time.Second
+}
+
+// isRequestFresh reports whether a stored entry satisfies the freshness
+// constraints carried by the client's own Cache-Control header.
+func isRequestFresh(d cacheControlDirectives, e *entry, ts uint64) bool {
+	if d.noCache {
+		return false
+	}
+
+	if ts >= e.exp {
+		// Entry is past its normal freshness lifetime: only max-stale can save
+		// it, and not even that if the origin sent must-revalidate/
+		// proxy-revalidate - RFC 7234 §5.2.2.1/2 forbid serving it stale once
+		// expired, regardless of what the client's max-stale would otherwise
+		// accept.
+		if e.originMustRevalidate || !d.hasMaxStale {
+			return false
+		}
+		return d.maxStaleAny || int64(ts-e.exp) <= d.maxStale
+	}
+
+	if d.hasMaxAge && int64(ts-e.stored) > d.maxAge {
+		return false
+	}
+	if d.hasMinFresh && int64(e.exp-ts) < d.minFresh {
+		return false
+	}
+
+	return true
+}
+
+// computeExpiration resolves how many seconds a response should stay fresh
+// for, preferring (in order) the origin's own Cache-Control/Expires headers,
+// a configured ExpirationGenerator, and finally cfg.Expiration.
+func computeExpiration(cfg Config, c *fiber.Ctx, d cacheControlDirectives) uint64 {
+	expiration := uint64(cfg.Expiration.Seconds())
+	if cfg.ExpirationGenerator != nil {
+		expiration = uint64(cfg.ExpirationGenerator(c, &cfg).Seconds())
+	}
+	if *cfg.HonorResponseDirectives {
+		if age, ok := responseExpiration(d, c); ok {
+			expiration = age
+		}
+	}
+	return expiration
+}
+
+// computeStaleWindows resolves the absolute expiry timestamps up to which a
+// stale entry may still be served: staleExp for a background
+// stale-while-revalidate refresh, errorExp for a stale-if-error fallback.
+// Both derive from exp plus a grace period in seconds, preferring (when
+// HonorResponseDirectives is enabled) the origin's own
+// "stale-while-revalidate"/"stale-if-error" Cache-Control directives over
+// cfg.StaleWhileRevalidate/StaleIfError. A zero result means that grace
+// period is disabled.
+func computeStaleWindows(cfg Config, c *fiber.Ctx, d cacheControlDirectives, exp uint64) (staleExp, errorExp uint64) {
+	swr := uint64(cfg.StaleWhileRevalidate.Seconds())
+	sie := uint64(cfg.StaleIfError.Seconds())
+
+	if *cfg.HonorResponseDirectives {
+		if d.hasStaleWhileRevalidate && d.staleWhileRevalidate >= 0 {
+			swr = uint64(d.staleWhileRevalidate)
+		}
+		if d.hasStaleIfError && d.staleIfError >= 0 {
+			sie = uint64(d.staleIfError)
+		}
+	}
+
+	if swr > 0 {
+		staleExp = exp + swr
+	}
+	if sie > 0 {
+		errorExp = exp + sie
+	}
+	return
+}
+
+// responseExpiration computes the freshness lifetime (in seconds) implied by
+// an origin response's Cache-Control and Expires headers. The second return
+// value is false when neither header yields a usable value, in which case
+// the caller should fall back to its own default/generator.
+func responseExpiration(d cacheControlDirectives, c *fiber.Ctx) (uint64, bool) {
+	// A shared cache must prefer s-maxage over max-age when both are present.
+	if d.hasSMaxAge && d.sMaxAge >= 0 {
+		return uint64(d.sMaxAge), true
+	}
+	if d.hasMaxAge && d.maxAge >= 0 {
+		return uint64(d.maxAge), true
+	}
+
+	if expires := c.Response().Header.Peek(fiber.HeaderExpires); len(expires) > 0 {
+		if t, err := http.ParseTime(string(expires)); err == nil {
+			if secs := int64(time.Until(t).Seconds()); secs > 0 {
+				return uint64(secs), true
+			}
+			return 0, true
+		}
+	}
+
+	return 0, false
 }
@@ -0,0 +1,249 @@
+package cache
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+// Test_Cache_Response_NoStore_Bypass verifies that a response carrying
+// Cache-Control: no-store is never stored: every request reaches the origin.
+func Test_Cache_Response_NoStore_Bypass(t *testing.T) {
+	app := fiber.New()
+	app.Use(New())
+
+	calls := 0
+	app.Get("/", func(c *fiber.Ctx) error {
+		calls++
+		c.Set(fiber.HeaderCacheControl, "no-store")
+		return c.SendString("hello")
+	})
+
+	for i := 0; i < 2; i++ {
+		resp, err := app.Test(httptest.NewRequest("GET", "/", nil))
+		utils.AssertEqual(t, nil, err)
+		utils.AssertEqual(t, cacheUnreachable, resp.Header.Get("X-Cache"))
+	}
+	utils.AssertEqual(t, 2, calls)
+}
+
+// Test_Cache_Response_Private_Bypass verifies that a response carrying
+// Cache-Control: private is never stored, same as no-store.
+func Test_Cache_Response_Private_Bypass(t *testing.T) {
+	app := fiber.New()
+	app.Use(New())
+
+	calls := 0
+	app.Get("/", func(c *fiber.Ctx) error {
+		calls++
+		c.Set(fiber.HeaderCacheControl, "private")
+		return c.SendString("hello")
+	})
+
+	for i := 0; i < 2; i++ {
+		resp, err := app.Test(httptest.NewRequest("GET", "/", nil))
+		utils.AssertEqual(t, nil, err)
+		utils.AssertEqual(t, cacheUnreachable, resp.Header.Get("X-Cache"))
+	}
+	utils.AssertEqual(t, 2, calls)
+}
+
+// Test_Cache_Response_NoCache_AlwaysRevalidates verifies that a response
+// carrying Cache-Control: no-cache is stored, but - absent a validator to
+// revalidate against - is never served back without going to the origin
+// again, even while still within its freshness lifetime.
+func Test_Cache_Response_NoCache_AlwaysRevalidates(t *testing.T) {
+	app := fiber.New()
+	app.Use(New(Config{Expiration: 1 * time.Minute}))
+
+	calls := 0
+	app.Get("/", func(c *fiber.Ctx) error {
+		calls++
+		c.Set(fiber.HeaderCacheControl, "no-cache")
+		return c.SendString("hello")
+	})
+
+	for i := 0; i < 2; i++ {
+		resp, err := app.Test(httptest.NewRequest("GET", "/", nil))
+		utils.AssertEqual(t, nil, err)
+		utils.AssertEqual(t, cacheMiss, resp.Header.Get("X-Cache"))
+	}
+	utils.AssertEqual(t, 2, calls)
+}
+
+// Test_Cache_Response_MaxAge_OverridesExpiration verifies that a response's
+// own max-age directive determines its freshness lifetime instead of
+// cfg.Expiration.
+func Test_Cache_Response_MaxAge_OverridesExpiration(t *testing.T) {
+	app := fiber.New()
+	app.Use(New(Config{Expiration: 1 * time.Minute}))
+
+	calls := 0
+	app.Get("/", func(c *fiber.Ctx) error {
+		calls++
+		c.Set(fiber.HeaderCacheControl, "max-age=1")
+		return c.SendString("hello")
+	})
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, cacheMiss, resp.Header.Get("X-Cache"))
+	utils.AssertEqual(t, 1, calls)
+
+	// cfg.Expiration would keep this fresh for another minute; the
+	// response's own max-age=1 must win instead.
+	time.Sleep(1100 * time.Millisecond)
+
+	resp, err = app.Test(httptest.NewRequest("GET", "/", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, cacheMiss, resp.Header.Get("X-Cache"))
+	utils.AssertEqual(t, 2, calls)
+}
+
+// Test_Cache_Response_SMaxAge_PreferredOverMaxAge verifies that, when both
+// are present, s-maxage is preferred over max-age, per RFC 7234 §5.2.2.9.
+func Test_Cache_Response_SMaxAge_PreferredOverMaxAge(t *testing.T) {
+	app := fiber.New()
+	app.Use(New(Config{Expiration: 1 * time.Minute}))
+
+	calls := 0
+	app.Get("/", func(c *fiber.Ctx) error {
+		calls++
+		c.Set(fiber.HeaderCacheControl, "max-age=60, s-maxage=1")
+		return c.SendString("hello")
+	})
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, cacheMiss, resp.Header.Get("X-Cache"))
+	utils.AssertEqual(t, 1, calls)
+
+	// max-age=60 would keep this fresh; s-maxage=1 must take precedence.
+	time.Sleep(1100 * time.Millisecond)
+
+	resp, err = app.Test(httptest.NewRequest("GET", "/", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, cacheMiss, resp.Header.Get("X-Cache"))
+	utils.AssertEqual(t, 2, calls)
+}
+
+// Test_Cache_Response_Expires_OverridesExpiration verifies that an Expires
+// header is honored as a freshness-lifetime fallback when no max-age/
+// s-maxage directive is present.
+func Test_Cache_Response_Expires_OverridesExpiration(t *testing.T) {
+	app := fiber.New()
+	app.Use(New(Config{Expiration: 1 * time.Minute}))
+
+	calls := 0
+	app.Get("/", func(c *fiber.Ctx) error {
+		calls++
+		c.Set(fiber.HeaderExpires, time.Now().Add(2*time.Second).UTC().Format(http.TimeFormat))
+		return c.SendString("hello")
+	})
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, cacheMiss, resp.Header.Get("X-Cache"))
+	utils.AssertEqual(t, 1, calls)
+
+	// cfg.Expiration would keep this fresh for another minute; the
+	// response's own Expires (~2s out) must win instead.
+	time.Sleep(2200 * time.Millisecond)
+
+	resp, err = app.Test(httptest.NewRequest("GET", "/", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, cacheMiss, resp.Header.Get("X-Cache"))
+	utils.AssertEqual(t, 2, calls)
+}
+
+// Test_Cache_Request_MaxAgeZero_ForcesRevalidation verifies that a client
+// sending Cache-Control: max-age=0 forces the entry to be treated as too old
+// to serve as-is, even while it's still within its own freshness lifetime.
+func Test_Cache_Request_MaxAgeZero_ForcesRevalidation(t *testing.T) {
+	app := fiber.New()
+	app.Use(New(Config{Expiration: 1 * time.Minute}))
+
+	calls := 0
+	app.Get("/", func(c *fiber.Ctx) error {
+		calls++
+		return c.SendString("hello")
+	})
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, cacheMiss, resp.Header.Get("X-Cache"))
+	utils.AssertEqual(t, 1, calls)
+
+	// Let the entry age by at least a full second so its age is non-zero,
+	// then ask for max-age=0: it's still far from cfg.Expiration, so without
+	// honoring the request's own directive it would otherwise be a hit.
+	time.Sleep(1100 * time.Millisecond)
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(fiber.HeaderCacheControl, "max-age=0")
+	resp, err = app.Test(req)
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, cacheMiss, resp.Header.Get("X-Cache"))
+	utils.AssertEqual(t, 2, calls)
+}
+
+// Test_Cache_Request_MaxStale_ServesExpiredEntry verifies that a client
+// sending Cache-Control: max-stale accepts an entry past its freshness
+// lifetime, served directly without involving the origin.
+func Test_Cache_Request_MaxStale_ServesExpiredEntry(t *testing.T) {
+	app := fiber.New()
+	app.Use(New(Config{Expiration: 1 * time.Second}))
+
+	calls := 0
+	app.Get("/", func(c *fiber.Ctx) error {
+		calls++
+		return c.SendString("hello")
+	})
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, cacheMiss, resp.Header.Get("X-Cache"))
+	utils.AssertEqual(t, 1, calls)
+
+	time.Sleep(1100 * time.Millisecond)
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(fiber.HeaderCacheControl, "max-stale")
+	resp, err = app.Test(req)
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, cacheHit, resp.Header.Get("X-Cache"))
+	utils.AssertEqual(t, 1, calls)
+}
+
+// Test_Cache_Response_MustRevalidate_ForbidsMaxStale verifies that an origin
+// response carrying must-revalidate/proxy-revalidate cannot be served stale
+// to a client's max-stale once it has expired, unlike a plain response.
+func Test_Cache_Response_MustRevalidate_ForbidsMaxStale(t *testing.T) {
+	app := fiber.New()
+	app.Use(New(Config{Expiration: 1 * time.Second}))
+
+	calls := 0
+	app.Get("/", func(c *fiber.Ctx) error {
+		calls++
+		c.Set(fiber.HeaderCacheControl, "must-revalidate")
+		return c.SendString("hello")
+	})
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, cacheMiss, resp.Header.Get("X-Cache"))
+	utils.AssertEqual(t, 1, calls)
+
+	time.Sleep(1100 * time.Millisecond)
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(fiber.HeaderCacheControl, "max-stale")
+	resp, err = app.Test(req)
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, cacheMiss, resp.Header.Get("X-Cache"))
+	utils.AssertEqual(t, 2, calls)
+}
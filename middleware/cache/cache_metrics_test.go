@@ -0,0 +1,47 @@
+package cache
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+// Test_Cache_LRU_Eviction verifies that MaxEntries bounds the number of
+// distinct cache keys, evicting the least recently used entry once
+// exceeded, and that Metrics reflects the entry count and evictions involved.
+func Test_Cache_LRU_Eviction(t *testing.T) {
+	app := fiber.New()
+
+	handler, metrics := NewWithMetrics(Config{MaxEntries: 2})
+	app.Use(handler)
+
+	app.Get("/:id", func(c *fiber.Ctx) error {
+		return c.SendString(c.Params("id"))
+	})
+
+	for _, id := range []string{"a", "b"} {
+		resp, err := app.Test(httptest.NewRequest("GET", "/"+id, nil))
+		utils.AssertEqual(t, nil, err)
+		utils.AssertEqual(t, cacheMiss, resp.Header.Get("X-Cache"))
+	}
+	utils.AssertEqual(t, int64(2), metrics.Entries)
+
+	// A third distinct key exceeds MaxEntries: the coldest entry ("a",
+	// least recently used) must be evicted to make room.
+	resp, err := app.Test(httptest.NewRequest("GET", "/c", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, cacheMiss, resp.Header.Get("X-Cache"))
+
+	utils.AssertEqual(t, int64(2), metrics.Entries)
+	utils.AssertEqual(t, uint64(1), metrics.Evictions)
+
+	resp, err = app.Test(httptest.NewRequest("GET", "/a", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, cacheMiss, resp.Header.Get("X-Cache"))
+
+	resp, err = app.Test(httptest.NewRequest("GET", "/c", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, cacheHit, resp.Header.Get("X-Cache"))
+}
@@ -0,0 +1,69 @@
+package cache
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+// Test_Cache_Client_NotModified verifies that a client's own conditional
+// request (If-None-Match) against a cached entry is answered with a
+// bodiless 304 instead of the full cached response.
+func Test_Cache_Client_NotModified(t *testing.T) {
+	app := fiber.New()
+
+	app.Use(New())
+
+	app.Get("/", func(c *fiber.Ctx) error {
+		c.Set(fiber.HeaderETag, `"v1"`)
+		return c.SendString("hello")
+	})
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, cacheMiss, resp.Header.Get("X-Cache"))
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(fiber.HeaderIfNoneMatch, `"v1"`)
+	resp, err = app.Test(req)
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, fiber.StatusNotModified, resp.StatusCode)
+	utils.AssertEqual(t, cacheHit, resp.Header.Get("X-Cache"))
+}
+
+// Test_Cache_Origin_Revalidation verifies that once a cached entry carrying
+// a validator goes stale, the middleware revalidates it against the origin
+// (via If-None-Match) instead of discarding it outright, and re-serves the
+// existing cached body on a 304.
+func Test_Cache_Origin_Revalidation(t *testing.T) {
+	app := fiber.New()
+
+	app.Use(New(Config{Expiration: 1 * time.Second}))
+
+	calls := 0
+	app.Get("/", func(c *fiber.Ctx) error {
+		calls++
+		c.Set(fiber.HeaderETag, `"v1"`)
+		if c.Get(fiber.HeaderIfNoneMatch) == `"v1"` {
+			return c.SendStatus(fiber.StatusNotModified)
+		}
+		return c.SendString("hello")
+	})
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, cacheMiss, resp.Header.Get("X-Cache"))
+	utils.AssertEqual(t, 1, calls)
+
+	// Past the 1s freshness lifetime, but the entry carries an ETag: the
+	// next request should revalidate against the origin, not refetch.
+	time.Sleep(1100 * time.Millisecond)
+
+	resp, err = app.Test(httptest.NewRequest("GET", "/", nil))
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, cacheRevalidated, resp.Header.Get("X-Cache"))
+	utils.AssertEqual(t, 2, calls)
+}
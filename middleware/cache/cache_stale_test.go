@@ -0,0 +1,62 @@
+package cache
+
+import (
+	"io"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+// Test_Cache_StaleWhileRevalidate verifies that a request landing past an
+// entry's freshness lifetime, but within its stale-while-revalidate grace
+// period, is served the stale body immediately while a background request
+// refreshes it - and that the refreshed body is visible afterwards. This
+// also exercises triggerBackgroundRefresh's replay of the handler chain,
+// which previously crashed the process on its first use.
+func Test_Cache_StaleWhileRevalidate(t *testing.T) {
+	app := fiber.New()
+
+	app.Use(New(Config{
+		Expiration:           1 * time.Second,
+		StaleWhileRevalidate: 5 * time.Second,
+	}))
+
+	var version uint64 = 1
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendString("v" + strconv.FormatUint(atomic.LoadUint64(&version), 10))
+	})
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/", nil))
+	utils.AssertEqual(t, nil, err)
+	body, err := io.ReadAll(resp.Body)
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, "v1", string(body))
+
+	// Past freshness but within the stale-while-revalidate window: the
+	// stale body is served right away, and a background refresh starts.
+	atomic.StoreUint64(&version, 2)
+	time.Sleep(1100 * time.Millisecond)
+
+	resp, err = app.Test(httptest.NewRequest("GET", "/", nil))
+	utils.AssertEqual(t, nil, err)
+	body, err = io.ReadAll(resp.Body)
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, cacheStale, resp.Header.Get("X-Cache"))
+	utils.AssertEqual(t, "v1", string(body))
+
+	// Give the background refresh time to complete, then confirm the
+	// entry was actually updated.
+	time.Sleep(200 * time.Millisecond)
+
+	resp, err = app.Test(httptest.NewRequest("GET", "/", nil))
+	utils.AssertEqual(t, nil, err)
+	body, err = io.ReadAll(resp.Body)
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, cacheHit, resp.Header.Get("X-Cache"))
+	utils.AssertEqual(t, "v2", string(body))
+}
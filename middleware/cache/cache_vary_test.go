@@ -0,0 +1,76 @@
+package cache
+
+import (
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+// Test_Cache_Vary_Header verifies that a response carrying a Vary header is
+// cached per distinct combination of the listed request header values,
+// instead of a single entry shared by every client.
+func Test_Cache_Vary_Header(t *testing.T) {
+	app := fiber.New()
+
+	app.Use(New())
+
+	app.Get("/", func(c *fiber.Ctx) error {
+		c.Set(fiber.HeaderVary, fiber.HeaderAcceptEncoding)
+		return c.SendString(c.Get(fiber.HeaderAcceptEncoding))
+	})
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(fiber.HeaderAcceptEncoding, "gzip")
+	resp, err := app.Test(req)
+	utils.AssertEqual(t, nil, err)
+	body, err := io.ReadAll(resp.Body)
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, "gzip", string(body))
+	utils.AssertEqual(t, cacheMiss, resp.Header.Get("X-Cache"))
+
+	// A different Accept-Encoding is a different variant: must miss again.
+	req = httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(fiber.HeaderAcceptEncoding, "br")
+	resp, err = app.Test(req)
+	utils.AssertEqual(t, nil, err)
+	body, err = io.ReadAll(resp.Body)
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, "br", string(body))
+	utils.AssertEqual(t, cacheMiss, resp.Header.Get("X-Cache"))
+
+	// Repeating the first variant's header value must now hit the cache and
+	// serve that variant's own body, not the second variant's.
+	req = httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(fiber.HeaderAcceptEncoding, "gzip")
+	resp, err = app.Test(req)
+	utils.AssertEqual(t, nil, err)
+	body, err = io.ReadAll(resp.Body)
+	utils.AssertEqual(t, nil, err)
+	utils.AssertEqual(t, "gzip", string(body))
+	utils.AssertEqual(t, cacheHit, resp.Header.Get("X-Cache"))
+}
+
+// Test_Cache_Vary_Star verifies that a "Vary: *" response is never cached,
+// since it can depend on anything.
+func Test_Cache_Vary_Star(t *testing.T) {
+	app := fiber.New()
+
+	app.Use(New())
+
+	calls := 0
+	app.Get("/", func(c *fiber.Ctx) error {
+		calls++
+		c.Set(fiber.HeaderVary, "*")
+		return c.SendString("ok")
+	})
+
+	for i := 0; i < 2; i++ {
+		resp, err := app.Test(httptest.NewRequest("GET", "/", nil))
+		utils.AssertEqual(t, nil, err)
+		utils.AssertEqual(t, cacheUnreachable, resp.Header.Get("X-Cache"))
+	}
+	utils.AssertEqual(t, 2, calls)
+}
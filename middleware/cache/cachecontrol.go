@@ -0,0 +1,101 @@
+package cache
+
+import (
+	"strconv"
+	"strings"
+)
+
+// cacheControlDirectives is the result of parsing a Cache-Control header,
+// used both for the response coming back from the handler chain and for the
+// request coming in from the client. Fields that were not present carry
+// their zero value; use the hasXxx flags to tell "absent" from "zero".
+type cacheControlDirectives struct {
+	noStore        bool
+	noCache        bool
+	private        bool
+	mustRevalidate bool
+
+	maxAge    int64
+	hasMaxAge bool
+
+	sMaxAge    int64
+	hasSMaxAge bool
+
+	minFresh    int64
+	hasMinFresh bool
+
+	// maxStale is only meaningful when hasMaxStale is true. maxStaleAny is true
+	// for a bare "max-stale" directive (no value), meaning any staleness is
+	// acceptable to the client.
+	maxStale    int64
+	hasMaxStale bool
+	maxStaleAny bool
+
+	// staleWhileRevalidate and staleIfError are the RFC 5861 grace periods
+	// (in seconds, past the normal freshness lifetime) during which a stale
+	// entry may still be served while a background refresh is in flight, or
+	// in place of an upstream error, respectively.
+	staleWhileRevalidate    int64
+	hasStaleWhileRevalidate bool
+
+	staleIfError    int64
+	hasStaleIfError bool
+}
+
+// parseCacheControl parses a raw Cache-Control header value into its
+// directives. Unknown directives are ignored, malformed numeric values are
+// dropped rather than rejecting the whole header.
+func parseCacheControl(header string) cacheControlDirectives {
+	var d cacheControlDirectives
+
+	for _, part := range strings.Split(header, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		key, value, _ := strings.Cut(part, "=")
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.Trim(strings.TrimSpace(value), `"`)
+
+		switch key {
+		case "no-store":
+			d.noStore = true
+		case "no-cache":
+			d.noCache = true
+		case "private":
+			d.private = true
+		case "must-revalidate", "proxy-revalidate":
+			d.mustRevalidate = true
+		case "max-age":
+			if v, err := strconv.ParseInt(value, 10, 64); err == nil {
+				d.maxAge, d.hasMaxAge = v, true
+			}
+		case "s-maxage":
+			if v, err := strconv.ParseInt(value, 10, 64); err == nil {
+				d.sMaxAge, d.hasSMaxAge = v, true
+			}
+		case "min-fresh":
+			if v, err := strconv.ParseInt(value, 10, 64); err == nil {
+				d.minFresh, d.hasMinFresh = v, true
+			}
+		case "max-stale":
+			d.hasMaxStale = true
+			if value == "" {
+				d.maxStaleAny = true
+			} else if v, err := strconv.ParseInt(value, 10, 64); err == nil {
+				d.maxStale = v
+			}
+		case "stale-while-revalidate":
+			if v, err := strconv.ParseInt(value, 10, 64); err == nil {
+				d.staleWhileRevalidate, d.hasStaleWhileRevalidate = v, true
+			}
+		case "stale-if-error":
+			if v, err := strconv.ParseInt(value, 10, 64); err == nil {
+				d.staleIfError, d.hasStaleIfError = v, true
+			}
+		}
+	}
+
+	return d
+}
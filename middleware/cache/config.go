@@ -0,0 +1,190 @@
+package cache
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Config defines the config for middleware.
+type Config struct {
+	// Next defines a function to skip this middleware when returned true.
+	//
+	// Optional. Default: nil
+	Next func(c *fiber.Ctx) bool
+
+	// Expiration is the time that an cached response will live.
+	//
+	// Optional. Default: 1 * time.Minute
+	Expiration time.Duration
+
+	// CacheHeader header on response header, indicate cache status, with the following possible return value
+	//
+	// hit, miss, unreachable, revalidated, stale
+	//
+	// Optional. Default: X-Cache
+	CacheHeader string
+
+	// CacheControl enables client side caching if set to true
+	//
+	// Optional. Default: false
+	CacheControl bool
+
+	// KeyGenerator allows you to generate custom keys, by default c.Path() is used
+	//
+	// Default: func(c *fiber.Ctx) string {
+	//   return c.Path()
+	// }
+	KeyGenerator func(c *fiber.Ctx) string
+
+	// ExpirationGenerator allows you to generate custom Expiration Key By Key, default is Expiration
+	//
+	// Optional. Default: nil
+	ExpirationGenerator func(c *fiber.Ctx, cfg *Config) time.Duration
+
+	// Storage is used to store the state of the middleware
+	//
+	// Default: an in memory store
+	Storage fiber.Storage
+
+	// StoreResponseHeaders allows you to store additional headers generated by next middlewares & handler.
+	//
+	// Optional. Default: false
+	StoreResponseHeaders bool
+
+	// HonorResponseDirectives makes the middleware interpret the origin response's
+	// Cache-Control header as a real shared cache would (RFC 7234): "no-store" and
+	// "private" responses are never cached, "no-cache" responses are cached but
+	// must be revalidated before being served, and "max-age"/"s-maxage" (or the
+	// Expires header as a fallback) override cfg.Expiration / ExpirationGenerator.
+	//
+	// This is a *bool, not a bool, so configDefault can tell "not set" (nil)
+	// apart from an explicit false and restore the documented true default -
+	// the zero Config{} literal that almost every caller passes would
+	// otherwise silently disable it.
+	//
+	// Optional. Default: true
+	HonorResponseDirectives *bool
+
+	// HonorRequestDirectives makes the middleware interpret the incoming request's
+	// Cache-Control header: "no-store" bypasses the cache entirely, "no-cache"
+	// keeps a stored entry from being served without going to the handler chain
+	// (whose response is then stored as usual), while "max-age", "min-fresh" and
+	// "max-stale" affect whether a stored entry is considered fresh enough to serve.
+	//
+	// This is a *bool for the same reason as HonorResponseDirectives above.
+	//
+	// Optional. Default: true
+	HonorRequestDirectives *bool
+
+	// HonorVaryHeader makes the middleware store a separate cache entry per
+	// distinct combination of the request header values listed in the
+	// origin response's Vary header, instead of a single entry shared by
+	// every client regardless of e.g. Accept-Encoding or Accept-Language.
+	// A response carrying "Vary: *" always bypasses the cache.
+	//
+	// This is a *bool for the same reason as HonorResponseDirectives above.
+	//
+	// Optional. Default: true
+	HonorVaryHeader *bool
+
+	// StaleWhileRevalidate lets a cached entry be served for up to this long
+	// past its normal freshness lifetime while a single background request
+	// refreshes it, per RFC 5861. The origin's own "stale-while-revalidate"
+	// Cache-Control directive takes precedence over this value when
+	// HonorResponseDirectives is enabled.
+	//
+	// Optional. Default: 0 (disabled)
+	StaleWhileRevalidate time.Duration
+
+	// StaleIfError lets a cached entry be served for up to this long past its
+	// normal freshness lifetime when refreshing it fails or returns a 5xx
+	// status, per RFC 5861. The origin's own "stale-if-error" Cache-Control
+	// directive takes precedence over this value when HonorResponseDirectives
+	// is enabled.
+	//
+	// Optional. Default: 0 (disabled)
+	StaleIfError time.Duration
+
+	// MaxBytes bounds the total size, in bytes, of cached entries (body plus
+	// the headers needed to replay them). Once exceeded, the least recently
+	// used entries are evicted on insert until the cache fits again,
+	// regardless of which Storage is in use.
+	//
+	// Optional. Default: 0 (unbounded)
+	MaxBytes int
+
+	// MaxEntries bounds the number of distinct cache keys. Once exceeded, the
+	// least recently used entries are evicted on insert until the cache fits
+	// again, regardless of which Storage is in use.
+	//
+	// Optional. Default: 0 (unbounded)
+	MaxEntries int
+
+	// OnEvict, if set, is called whenever MaxBytes or MaxEntries forces the
+	// LRU overlay to drop an entry, naming the evicted key and the reason.
+	//
+	// Optional. Default: nil
+	OnEvict func(key string, reason EvictReason)
+}
+
+// boolPtr returns a pointer to b, for populating the *bool "honor" defaults below.
+func boolPtr(b bool) *bool { return &b }
+
+// ConfigDefault is the default config
+var ConfigDefault = Config{
+	Next:         nil,
+	Expiration:   1 * time.Minute,
+	CacheHeader:  "X-Cache",
+	CacheControl: false,
+	KeyGenerator: func(c *fiber.Ctx) string {
+		return c.Path()
+	},
+	ExpirationGenerator:     nil,
+	StoreResponseHeaders:    false,
+	Storage:                 nil,
+	HonorResponseDirectives: boolPtr(true),
+	HonorRequestDirectives:  boolPtr(true),
+	HonorVaryHeader:         boolPtr(true),
+	StaleWhileRevalidate:    0,
+	StaleIfError:            0,
+	MaxBytes:                0,
+	MaxEntries:              0,
+	OnEvict:                 nil,
+}
+
+// Helper function to set default values
+func configDefault(config ...Config) Config {
+	// Return default config if nothing provided
+	if len(config) < 1 {
+		return ConfigDefault
+	}
+
+	// Override default config
+	cfg := config[0]
+
+	// Set default values
+	if cfg.Next == nil {
+		cfg.Next = ConfigDefault.Next
+	}
+	if int(cfg.Expiration.Seconds()) == 0 {
+		cfg.Expiration = ConfigDefault.Expiration
+	}
+	if cfg.CacheHeader == "" {
+		cfg.CacheHeader = ConfigDefault.CacheHeader
+	}
+	if cfg.KeyGenerator == nil {
+		cfg.KeyGenerator = ConfigDefault.KeyGenerator
+	}
+	if cfg.HonorResponseDirectives == nil {
+		cfg.HonorResponseDirectives = ConfigDefault.HonorResponseDirectives
+	}
+	if cfg.HonorRequestDirectives == nil {
+		cfg.HonorRequestDirectives = ConfigDefault.HonorRequestDirectives
+	}
+	if cfg.HonorVaryHeader == nil {
+		cfg.HonorVaryHeader = ConfigDefault.HonorVaryHeader
+	}
+
+	return cfg
+}
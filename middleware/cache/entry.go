@@ -0,0 +1,223 @@
+package cache
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// errCorruptEntry is returned by entry.UnmarshalBinary when the stored bytes
+// cannot be decoded, e.g. when the on-disk format of an external Storage
+// predates a field added to entry.
+var errCorruptEntry = errors.New("cache: corrupt entry")
+
+// MarshalBinary encodes an entry so it can be handed to an external
+// fiber.Storage implementation. The body is intentionally excluded: it is
+// stored separately to avoid paying its encoding cost on every lookup.
+func (e *entry) MarshalBinary() ([]byte, error) {
+	buf := new(bytes.Buffer)
+
+	writeUint64(buf, e.stored)
+	writeUint64(buf, e.exp)
+	writeBool(buf, e.mustRevalidate)
+	writeBool(buf, e.originMustRevalidate)
+	writeBytes(buf, e.etag)
+	writeBytes(buf, e.lastModified)
+	writeUint64(buf, e.staleExp)
+	writeUint64(buf, e.errorExp)
+	writeInt(buf, e.status)
+	writeBytes(buf, e.ctype)
+	writeBytes(buf, e.cencoding)
+
+	writeUint64(buf, uint64(len(e.headers)))
+	for k, v := range e.headers {
+		writeString(buf, k)
+		writeBytes(buf, v)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary decodes an entry previously produced by MarshalBinary.
+func (e *entry) UnmarshalBinary(data []byte) error {
+	buf := bytes.NewReader(data)
+
+	stored, err := readUint64(buf)
+	if err != nil {
+		return errCorruptEntry
+	}
+	exp, err := readUint64(buf)
+	if err != nil {
+		return errCorruptEntry
+	}
+	mustRevalidate, err := readBool(buf)
+	if err != nil {
+		return errCorruptEntry
+	}
+	originMustRevalidate, err := readBool(buf)
+	if err != nil {
+		return errCorruptEntry
+	}
+	etag, err := readBytes(buf)
+	if err != nil {
+		return errCorruptEntry
+	}
+	lastModified, err := readBytes(buf)
+	if err != nil {
+		return errCorruptEntry
+	}
+	staleExp, err := readUint64(buf)
+	if err != nil {
+		return errCorruptEntry
+	}
+	errorExp, err := readUint64(buf)
+	if err != nil {
+		return errCorruptEntry
+	}
+	status, err := readInt(buf)
+	if err != nil {
+		return errCorruptEntry
+	}
+	ctype, err := readBytes(buf)
+	if err != nil {
+		return errCorruptEntry
+	}
+	cencoding, err := readBytes(buf)
+	if err != nil {
+		return errCorruptEntry
+	}
+	headerCount, err := readUint64(buf)
+	if err != nil {
+		return errCorruptEntry
+	}
+
+	headers := make(map[string][]byte, headerCount)
+	for i := uint64(0); i < headerCount; i++ {
+		k, err := readString(buf)
+		if err != nil {
+			return errCorruptEntry
+		}
+		v, err := readBytes(buf)
+		if err != nil {
+			return errCorruptEntry
+		}
+		headers[k] = v
+	}
+
+	e.stored = stored
+	e.exp = exp
+	e.mustRevalidate = mustRevalidate
+	e.originMustRevalidate = originMustRevalidate
+	e.etag = etag
+	e.lastModified = lastModified
+	e.staleExp = staleExp
+	e.errorExp = errorExp
+	e.status = status
+	e.ctype = ctype
+	e.cencoding = cencoding
+	if len(headers) > 0 {
+		e.headers = headers
+	}
+
+	return nil
+}
+
+// marshalBinary encodes a varyDescriptor for external Storage.
+func (vd *varyDescriptor) marshalBinary() ([]byte, error) {
+	buf := new(bytes.Buffer)
+	writeUint64(buf, uint64(len(vd.names)))
+	for _, name := range vd.names {
+		writeString(buf, name)
+	}
+	return buf.Bytes(), nil
+}
+
+// unmarshalBinary decodes a varyDescriptor previously produced by marshalBinary.
+func (vd *varyDescriptor) unmarshalBinary(data []byte) error {
+	buf := bytes.NewReader(data)
+
+	count, err := readUint64(buf)
+	if err != nil {
+		return errCorruptEntry
+	}
+	names := make([]string, 0, count)
+	for i := uint64(0); i < count; i++ {
+		name, err := readString(buf)
+		if err != nil {
+			return errCorruptEntry
+		}
+		names = append(names, name)
+	}
+
+	vd.names = names
+	return nil
+}
+
+func writeUint64(buf *bytes.Buffer, v uint64) {
+	var tmp [8]byte
+	binary.BigEndian.PutUint64(tmp[:], v)
+	buf.Write(tmp[:])
+}
+
+func readUint64(r io.Reader) (uint64, error) {
+	var tmp [8]byte
+	if _, err := io.ReadFull(r, tmp[:]); err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint64(tmp[:]), nil
+}
+
+func writeBool(buf *bytes.Buffer, v bool) {
+	if v {
+		buf.WriteByte(1)
+	} else {
+		buf.WriteByte(0)
+	}
+}
+
+func readBool(r io.Reader) (bool, error) {
+	var tmp [1]byte
+	if _, err := io.ReadFull(r, tmp[:]); err != nil {
+		return false, err
+	}
+	return tmp[0] != 0, nil
+}
+
+func writeInt(buf *bytes.Buffer, v int) {
+	writeUint64(buf, uint64(v))
+}
+
+func readInt(r io.Reader) (int, error) {
+	v, err := readUint64(r)
+	return int(v), err
+}
+
+func writeBytes(buf *bytes.Buffer, v []byte) {
+	writeUint64(buf, uint64(len(v)))
+	buf.Write(v)
+}
+
+func readBytes(r io.Reader) ([]byte, error) {
+	n, err := readUint64(r)
+	if err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		return nil, nil
+	}
+	v := make([]byte, n)
+	if _, err := io.ReadFull(r, v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func writeString(buf *bytes.Buffer, v string) {
+	writeBytes(buf, []byte(v))
+}
+
+func readString(r io.Reader) (string, error) {
+	v, err := readBytes(r)
+	return string(v), err
+}
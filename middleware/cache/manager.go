@@ -0,0 +1,376 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/internal/memory"
+)
+
+// entry holds everything the middleware needs to reconstruct a cached
+// response without involving the next handler in the chain.
+type entry struct {
+	body, ctype, cencoding []byte
+	status                 int
+	// stored is the unix timestamp at which the entry was cached, used to
+	// compute its age against client Cache-Control directives.
+	stored uint64
+	// exp is the unix timestamp after which the entry is no longer fresh.
+	exp uint64
+	// mustRevalidate is set when the origin response carried Cache-Control:
+	// no-cache - the entry may be stored, but must not be served as fresh
+	// without revalidation against the origin.
+	mustRevalidate bool
+	// originMustRevalidate is set when the origin response carried
+	// Cache-Control: must-revalidate/proxy-revalidate - unlike mustRevalidate,
+	// it doesn't affect serving the entry while fresh, but forbids serving it
+	// stale under a client's max-stale once it has expired (RFC 7234
+	// §5.2.2.1/2).
+	originMustRevalidate bool
+	// etag and lastModified are the validators from the origin response, if
+	// any, used to answer client conditional requests and to revalidate a
+	// stale entry with the origin instead of always refetching its body.
+	etag, lastModified []byte
+	headers            map[string][]byte
+	// staleExp is the unix timestamp up to which the entry may still be
+	// served, past exp, while a background refresh (RFC 5861
+	// stale-while-revalidate) is in flight. Zero means no such grace period.
+	staleExp uint64
+	// errorExp is the unix timestamp up to which the entry may still be
+	// served, past exp, if refreshing it fails or errors (RFC 5861
+	// stale-if-error). Zero means no such grace period.
+	errorExp uint64
+}
+
+// varyDescriptor records the (normalized) request header names a cached
+// resource varies on, as advertised by its Vary response header. It is
+// stored separately from the entry it describes so a lookup can be remapped
+// to the right per-variant key before the regular entry machinery is involved.
+type varyDescriptor struct {
+	names []string
+}
+
+// EvictReason identifies why manager's LRU overlay removed an entry,
+// passed to Config.OnEvict.
+type EvictReason int
+
+const (
+	// EvictReasonCapacity means the entry was the coldest one on hand and was
+	// removed to bring the cache back under Config.MaxBytes/MaxEntries after
+	// inserting a new one.
+	EvictReasonCapacity EvictReason = iota
+)
+
+// Metrics exposes live counters for a cache middleware instance returned by
+// NewWithMetrics, suitable for serving from an operator endpoint such as
+// "/cache/metrics". All fields are updated with sync/atomic operations, so
+// reading them while requests are being served takes the matching atomic
+// loads; a plain read of a field at that time is a data race.
+type Metrics struct {
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
+	// Entries and Bytes reflect the current size of manager's LRU overlay,
+	// and are therefore only meaningful when MaxBytes or MaxEntries is set.
+	Entries int64
+	Bytes   int64
+}
+
+// lruNode is the bookkeeping record manager keeps per key in its LRU
+// overlay. It tracks size independently of whatever Storage implementation
+// actually holds the entry, since the Storage interface itself only knows
+// about TTL-based expiry.
+type lruNode struct {
+	key  string
+	cost int
+}
+
+// manager wraps a fiber.Storage (or an in-memory fallback) to simplify
+// storing and retrieving cache entries, and layers an optional LRU eviction
+// policy on top so the key space stays bounded regardless of which Storage
+// is in use.
+type manager struct {
+	pool    sync.Pool
+	memory  *memory.Storage
+	storage fiber.Storage
+
+	maxBytes   int
+	maxEntries int
+	onEvict    func(key string, reason EvictReason)
+	metrics    *Metrics
+
+	// lru/lruIndex/lruMu are only initialized when maxBytes or maxEntries is
+	// set; a nil lru means the overlay is disabled and every operation below
+	// involving it is a no-op.
+	lruMu    sync.Mutex
+	lru      *list.List
+	lruIndex map[string]*list.Element
+}
+
+// newManager creates a new manager, falling back to an in-memory store when
+// no external Storage implementation is provided, and enabling the LRU
+// overlay when cfg requests a MaxBytes or MaxEntries bound.
+func newManager(cfg Config) *manager {
+	m := &manager{
+		pool: sync.Pool{
+			New: func() interface{} {
+				return new(entry)
+			},
+		},
+		maxBytes:   cfg.MaxBytes,
+		maxEntries: cfg.MaxEntries,
+		onEvict:    cfg.OnEvict,
+		metrics:    &Metrics{},
+	}
+	if cfg.Storage != nil {
+		m.storage = cfg.Storage
+	} else {
+		m.memory = memory.New()
+	}
+	if m.maxBytes > 0 || m.maxEntries > 0 {
+		m.lru = list.New()
+		m.lruIndex = make(map[string]*list.Element)
+	}
+	return m
+}
+
+// acquire returns a new entry from the pool
+func (m *manager) acquire() *entry {
+	return m.pool.Get().(*entry)
+}
+
+// release resets an entry and puts it back into the pool
+func (m *manager) release(e *entry) {
+	e.body = nil
+	e.status = 0
+	e.stored = 0
+	e.exp = 0
+	e.mustRevalidate = false
+	e.originMustRevalidate = false
+	e.etag = nil
+	e.lastModified = nil
+	e.ctype = nil
+	e.cencoding = nil
+	e.headers = nil
+	e.staleExp = 0
+	e.errorExp = 0
+	m.pool.Put(e)
+}
+
+// get retrieves the entry stored under key, or a fresh, empty entry if none exists
+func (m *manager) get(key string) *entry {
+	e := m.acquire()
+	if m.storage != nil {
+		raw, err := m.storage.Get(key)
+		if err != nil || raw == nil {
+			return e
+		}
+		if err := e.UnmarshalBinary(raw); err != nil {
+			return e
+		}
+	} else {
+		if v := m.memory.Get(key); v != nil {
+			e = v.(*entry)
+		} else {
+			return e
+		}
+	}
+	// A successful lookup is a use: keep it warm in the LRU overlay.
+	m.touch(key)
+	return e
+}
+
+// getRaw retrieves the raw bytes stored under key
+func (m *manager) getRaw(key string) []byte {
+	var raw []byte
+	if m.storage != nil {
+		raw, _ = m.storage.Get(key)
+	} else {
+		if v := m.memory.Get(key); v != nil {
+			raw, _ = v.([]byte)
+		}
+	}
+	return raw
+}
+
+// set stores an entry under key, serializing it for external storage.
+// bodyLen is the size of the response body in bytes, even if e.body has
+// already been split off into a separate "_body" key for external Storage;
+// it is needed to account the entry's true cost in the LRU overlay.
+func (m *manager) set(key string, e *entry, exp time.Duration, bodyLen int) {
+	m.trackInsert(key, entryCost(e, bodyLen))
+
+	if m.storage != nil {
+		if raw, err := e.MarshalBinary(); err == nil {
+			_ = m.storage.Set(key, raw, exp)
+		}
+	} else {
+		m.memory.Set(key, e, exp)
+	}
+}
+
+// setRaw stores raw bytes under key
+func (m *manager) setRaw(key string, raw []byte, exp time.Duration) {
+	if m.storage != nil {
+		_ = m.storage.Set(key, raw, exp)
+	} else {
+		m.memory.Set(key, raw, exp)
+	}
+}
+
+// delete removes key from storage and, if present, from the LRU overlay.
+func (m *manager) delete(key string) {
+	m.removeFromStore(key)
+	m.untrack(key)
+}
+
+// removeFromStore deletes key from the underlying Storage/memory map only,
+// without touching the LRU overlay; used by evictLocked, which maintains
+// the overlay's bookkeeping itself while already holding lruMu.
+func (m *manager) removeFromStore(key string) {
+	if m.storage != nil {
+		_ = m.storage.Delete(key)
+	} else {
+		m.memory.Delete(key)
+	}
+}
+
+// getVary retrieves the vary descriptor stored under key, or nil if there
+// isn't one (or it has expired out of the underlying storage).
+func (m *manager) getVary(key string) *varyDescriptor {
+	if m.storage != nil {
+		raw, err := m.storage.Get(key)
+		if err != nil || raw == nil {
+			return nil
+		}
+		vd := new(varyDescriptor)
+		if err := vd.unmarshalBinary(raw); err != nil {
+			return nil
+		}
+		return vd
+	}
+	v := m.memory.Get(key)
+	if v == nil {
+		return nil
+	}
+	vd, _ := v.(*varyDescriptor)
+	return vd
+}
+
+// setVary stores a vary descriptor under key.
+func (m *manager) setVary(key string, vd *varyDescriptor, exp time.Duration) {
+	if m.storage != nil {
+		if raw, err := vd.marshalBinary(); err == nil {
+			_ = m.storage.Set(key, raw, exp)
+		}
+		return
+	}
+	m.memory.Set(key, vd, exp)
+}
+
+// recordHit and recordMiss update the hit/miss counters surfaced via Metrics.
+func (m *manager) recordHit()  { atomic.AddUint64(&m.metrics.Hits, 1) }
+func (m *manager) recordMiss() { atomic.AddUint64(&m.metrics.Misses, 1) }
+
+// entryCost estimates the in-memory footprint of an entry's cached response:
+// its body plus the headers needed to replay it.
+func entryCost(e *entry, bodyLen int) int {
+	cost := bodyLen + len(e.ctype) + len(e.cencoding)
+	for k, v := range e.headers {
+		cost += len(k) + len(v)
+	}
+	return cost
+}
+
+// touch marks key as recently used, if it's tracked by the LRU overlay.
+func (m *manager) touch(key string) {
+	if m.lru == nil {
+		return
+	}
+	m.lruMu.Lock()
+	defer m.lruMu.Unlock()
+	if el, ok := m.lruIndex[key]; ok {
+		m.lru.MoveToFront(el)
+	}
+}
+
+// untrack removes key from the LRU overlay's bookkeeping, if present.
+func (m *manager) untrack(key string) {
+	if m.lru == nil {
+		return
+	}
+	m.lruMu.Lock()
+	defer m.lruMu.Unlock()
+	m.removeNodeLocked(key)
+}
+
+// trackInsert records (or updates) key's cost in the LRU overlay, moves it
+// to the front as the most-recently-used entry, and evicts the coldest
+// entries until the cache is back within its configured bounds.
+func (m *manager) trackInsert(key string, cost int) {
+	if m.lru == nil {
+		return
+	}
+	m.lruMu.Lock()
+	defer m.lruMu.Unlock()
+
+	if el, ok := m.lruIndex[key]; ok {
+		node := el.Value.(*lruNode)
+		atomic.AddInt64(&m.metrics.Bytes, int64(cost-node.cost))
+		node.cost = cost
+		m.lru.MoveToFront(el)
+	} else {
+		node := &lruNode{key: key, cost: cost}
+		m.lruIndex[key] = m.lru.PushFront(node)
+		atomic.AddInt64(&m.metrics.Bytes, int64(cost))
+		atomic.AddInt64(&m.metrics.Entries, 1)
+	}
+
+	m.evictLocked()
+}
+
+// removeNodeLocked drops key's bookkeeping from the LRU overlay. Callers
+// must hold lruMu.
+func (m *manager) removeNodeLocked(key string) {
+	el, ok := m.lruIndex[key]
+	if !ok {
+		return
+	}
+	node := el.Value.(*lruNode)
+	m.lru.Remove(el)
+	delete(m.lruIndex, key)
+	atomic.AddInt64(&m.metrics.Bytes, -int64(node.cost))
+	atomic.AddInt64(&m.metrics.Entries, -1)
+}
+
+// evictLocked removes the coldest entries until the overlay satisfies
+// maxBytes/maxEntries again, notifying cfg.OnEvict for each one. Callers
+// must hold lruMu; it deletes directly from the backing store rather than
+// through manager.delete to avoid re-acquiring lruMu.
+func (m *manager) evictLocked() {
+	for (m.maxEntries > 0 && len(m.lruIndex) > m.maxEntries) ||
+		(m.maxBytes > 0 && atomic.LoadInt64(&m.metrics.Bytes) > int64(m.maxBytes)) {
+		back := m.lru.Back()
+		if back == nil {
+			break
+		}
+		node := back.Value.(*lruNode)
+		m.removeNodeLocked(node.key)
+		m.removeFromStore(node.key)
+		if m.storage != nil {
+			// External Storage keeps the body under a separate key, not
+			// covered by removeFromStore above; its size is included in
+			// node.cost, so leaving it behind would let MaxBytes keep
+			// growing unbounded regardless of eviction.
+			m.removeFromStore(node.key + "_body")
+		}
+
+		atomic.AddUint64(&m.metrics.Evictions, 1)
+		if m.onEvict != nil {
+			m.onEvict(node.key, EvictReasonCapacity)
+		}
+	}
+}
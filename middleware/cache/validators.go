@@ -0,0 +1,61 @@
+package cache
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// notModified reports whether the client's own conditional request headers
+// (If-None-Match / If-Modified-Since) are satisfied by the entry's stored
+// validators, meaning a 304 can be returned instead of the full body.
+func notModified(c *fiber.Ctx, e *entry) bool {
+	if len(e.etag) > 0 {
+		if inm := c.Get(fiber.HeaderIfNoneMatch); inm != "" {
+			return etagMatches(inm, e.etag)
+		}
+	}
+	if len(e.lastModified) > 0 {
+		if ims := c.Get(fiber.HeaderIfModifiedSince); ims != "" {
+			since, err := http.ParseTime(ims)
+			if err != nil {
+				return false
+			}
+			lastModified, err := http.ParseTime(string(e.lastModified))
+			if err != nil {
+				return false
+			}
+			return !lastModified.After(since)
+		}
+	}
+	return false
+}
+
+// etagMatches reports whether any entity-tag in the (possibly list-valued)
+// If-None-Match header matches etag, ignoring the weak-validator prefix.
+func etagMatches(ifNoneMatch string, etag []byte) bool {
+	if ifNoneMatch == "*" {
+		return true
+	}
+	target := strings.TrimPrefix(string(etag), "W/")
+	for _, tok := range strings.Split(ifNoneMatch, ",") {
+		tok = strings.TrimPrefix(strings.TrimSpace(tok), "W/")
+		if tok == target {
+			return true
+		}
+	}
+	return false
+}
+
+// setValidatorHeaders writes the cached response's validators onto the
+// current response, so that a 304 (or a served-from-cache 200) carries the
+// same ETag/Last-Modified the origin would have sent.
+func setValidatorHeaders(c *fiber.Ctx, e *entry) {
+	if len(e.etag) > 0 {
+		c.Response().Header.SetBytesV(fiber.HeaderETag, e.etag)
+	}
+	if len(e.lastModified) > 0 {
+		c.Response().Header.SetBytesV(fiber.HeaderLastModified, e.lastModified)
+	}
+}
@@ -0,0 +1,65 @@
+package cache
+
+import (
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// listValuedVaryHeaders are headers whose value is itself a comma-separated,
+// order-insensitive list, e.g. "gzip, br" and "br, gzip" select the same
+// representation and must therefore normalize to the same variant key.
+var listValuedVaryHeaders = map[string]bool{
+	"accept-encoding": true,
+	"accept-language": true,
+	"accept":          true,
+}
+
+// normalizeVaryValue canonicalizes a single request header's value so that
+// equivalent values map to the same variant key regardless of casing,
+// whitespace or - for list-valued headers - token order.
+func normalizeVaryValue(header, value string) string {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if !listValuedVaryHeaders[header] {
+		return value
+	}
+	tokens := strings.Split(value, ",")
+	for i, t := range tokens {
+		tokens[i] = strings.TrimSpace(t)
+	}
+	sort.Strings(tokens)
+	return strings.Join(tokens, ",")
+}
+
+// parseVary splits a response's Vary header into normalized, sorted header
+// names. varyAll is true for "Vary: *", which must bypass the cache entirely.
+func parseVary(header string) (names []string, varyAll bool) {
+	for _, part := range strings.Split(header, ",") {
+		name := strings.ToLower(strings.TrimSpace(part))
+		if name == "" {
+			continue
+		}
+		if name == "*" {
+			return nil, true
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, false
+}
+
+// varyKeySuffix builds the secondary cache key suffix for the request's
+// current values of the given (already normalized) Vary header names.
+func varyKeySuffix(c *fiber.Ctx, varyHeaders []string) string {
+	h := fnv.New64a()
+	for _, header := range varyHeaders {
+		_, _ = h.Write([]byte(header))
+		_, _ = h.Write([]byte{0})
+		_, _ = h.Write([]byte(normalizeVaryValue(header, c.Get(header))))
+		_, _ = h.Write([]byte{0})
+	}
+	return "_vary_" + strconv.FormatUint(h.Sum64(), 36)
+}